@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// formCodec implements application/x-www-form-urlencoded.
+type formCodec struct{}
+
+func (formCodec) MediaTypes() []string { return []string{"application/x-www-form-urlencoded"} }
+
+func (formCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("form body must be a JSON object, got %T", v)
+	}
+
+	values := url.Values{}
+	for k, val := range m {
+		values.Set(k, fmt.Sprintf("%v", val))
+	}
+
+	return []byte(values.Encode()), nil
+}
+
+func (formCodec) Unmarshal(data []byte) (map[string]interface{}, error) {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		if len(v) == 1 {
+			m[k] = v[0]
+		} else {
+			m[k] = v
+		}
+	}
+
+	return m, nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// msgpackCodec implements application/x-msgpack.
+type msgpackCodec struct{}
+
+func (msgpackCodec) MediaTypes() []string { return []string{"application/x-msgpack"} }
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) Unmarshal(data []byte) (map[string]interface{}, error) {
+	var m map[string]interface{}
+	err := msgpack.Unmarshal(data, &m)
+	return m, err
+}
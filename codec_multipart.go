@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+)
+
+// multipartCodec implements multipart/form-data. A call's on.body is
+// expected to be a JSON array of parts:
+//
+//	"body": [
+//	  {"name": "title", "value": "my file"},
+//	  {"name": "file", "bodyFile": "payload.bin", "contentType": "application/octet-stream"}
+//	]
+//
+// bodyFile is resolved relative to the suite directory by encodeBody
+// before Marshal is called, same as Call.On.BodyFile.
+type multipartCodec struct{}
+
+func (multipartCodec) MediaTypes() []string { return []string{"multipart/form-data"} }
+
+// MultipartPart is one field of a multipart/form-data body.
+type MultipartPart struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitempty"`
+	BodyFile    string `json:"bodyFile,omitempty"`
+	Filename    string `json:"filename,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+func (multipartCodec) Marshal(v interface{}) ([]byte, error) {
+	parts, err := toMultipartParts(v)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	w := multipart.NewWriter(buf)
+
+	for _, p := range parts {
+		if p.BodyFile == "" {
+			if err := w.WriteField(p.Name, p.Value); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		data, err := ioutil.ReadFile(p.BodyFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't read multipart part %q: %s", p.Name, err.Error())
+		}
+
+		filename := p.Filename
+		if filename == "" {
+			filename = p.BodyFile
+		}
+
+		fw, err := w.CreatePart(partHeader(p.Name, filename, p.ContentType))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func partHeader(name, filename, contentType string) textproto.MIMEHeader {
+	h := textproto.MIMEHeader{
+		"Content-Disposition": {mime.FormatMediaType("form-data", map[string]string{"name": name, "filename": filename})},
+	}
+	if contentType != "" {
+		h.Set("Content-Type", contentType)
+	}
+	return h
+}
+
+func (multipartCodec) Unmarshal(data []byte) (map[string]interface{}, error) {
+	return nil, fmt.Errorf("multipart/form-data responses are not supported for remember/body expectations")
+}
+
+// toMultipartParts re-encodes the generic value decoded from on.body's
+// JSON array into []MultipartPart.
+func toMultipartParts(v interface{}) ([]MultipartPart, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var parts []MultipartPart
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return nil, fmt.Errorf("multipart body must be an array of parts: %s", err.Error())
+	}
+
+	return parts, nil
+}
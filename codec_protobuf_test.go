@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testProtoSrc = `syntax = "proto3";
+package order;
+
+message Order {
+  string id = 1;
+  int32 quantity = 2;
+}
+`
+
+func writeTestProto(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "order.proto")
+	if err := os.WriteFile(path, []byte(testProtoSrc), 0o644); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	return path
+}
+
+func TestProtobufCodecMarshalAndUnmarshalWithDescriptor(t *testing.T) {
+	protoFile := writeTestProto(t)
+
+	body := ProtoBody{
+		ProtoFile:    protoFile,
+		ProtoMessage: "order.Order",
+		JSON:         map[string]interface{}{"id": "o-1", "quantity": float64(3)},
+	}
+
+	encoded, err := (protobufCodec{}).Marshal(body)
+	if err != nil {
+		t.Fatalf("Marshal: %s", err)
+	}
+
+	decoded, err := UnmarshalWithDescriptor(protoFile, "order.Order", encoded)
+	if err != nil {
+		t.Fatalf("UnmarshalWithDescriptor: %s", err)
+	}
+
+	if decoded["id"] != "o-1" {
+		t.Errorf("decoded id = %v, want o-1", decoded["id"])
+	}
+	if decoded["quantity"] != float64(3) {
+		t.Errorf("decoded quantity = %v, want 3", decoded["quantity"])
+	}
+}
+
+func TestProtobufCodecMarshalRejectsWrongType(t *testing.T) {
+	if _, err := (protobufCodec{}).Marshal(map[string]interface{}{"id": "o-1"}); err == nil {
+		t.Error("expected an error when v isn't a ProtoBody")
+	}
+}
+
+func TestProtobufCodecUnmarshalIsUnreachable(t *testing.T) {
+	if _, err := (protobufCodec{}).Unmarshal([]byte("anything")); err == nil {
+		t.Error("expected Unmarshal to report it can't be used without a descriptor")
+	}
+}
+
+func TestUnmarshalWithDescriptorUnknownMessage(t *testing.T) {
+	protoFile := writeTestProto(t)
+
+	if _, err := UnmarshalWithDescriptor(protoFile, "order.NoSuchMessage", nil); err == nil {
+		t.Error("expected an error for an unknown message name")
+	}
+}
+
+func TestEncodeBodyResolvesProtoFileRelativeToSuiteDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "order.proto"), []byte(testProtoSrc), 0o644); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+
+	on := On{
+		Headers:      map[string]string{"Content-Type": "application/x-protobuf"},
+		ProtoFile:    "order.proto",
+		ProtoMessage: "order.Order",
+	}
+
+	encoded := encodeBody(on, `{"id":"o-1","quantity":3}`, dir)
+
+	decoded, err := UnmarshalWithDescriptor(filepath.Join(dir, "order.proto"), "order.Order", encoded)
+	if err != nil {
+		t.Fatalf("UnmarshalWithDescriptor: %s", err)
+	}
+	if decoded["id"] != "o-1" {
+		t.Errorf("decoded id = %v, want o-1", decoded["id"])
+	}
+}
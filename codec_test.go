@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormCodec(t *testing.T) {
+	c := formCodec{}
+
+	encoded, err := c.Marshal(map[string]interface{}{"name": "bozr"})
+	if err != nil {
+		t.Fatalf("Marshal: %s", err)
+	}
+	if string(encoded) != "name=bozr" {
+		t.Fatalf("Marshal = %q, want %q", encoded, "name=bozr")
+	}
+
+	decoded, err := c.Unmarshal(encoded)
+	if err != nil {
+		t.Fatalf("Unmarshal: %s", err)
+	}
+	if decoded["name"] != "bozr" {
+		t.Fatalf("Unmarshal = %+v, want name=bozr", decoded)
+	}
+}
+
+func TestFormCodecMarshalRejectsNonObject(t *testing.T) {
+	if _, err := (formCodec{}).Marshal([]interface{}{"not", "an", "object"}); err == nil {
+		t.Error("expected an error for a non-object body")
+	}
+}
+
+func TestMultipartCodecMarshal(t *testing.T) {
+	dir := t.TempDir()
+	payload := filepath.Join(dir, "payload.bin")
+	if err := os.WriteFile(payload, []byte("file contents"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+
+	parts := []MultipartPart{
+		{Name: "title", Value: "my file"},
+		{Name: "file", BodyFile: payload, ContentType: "application/octet-stream"},
+	}
+
+	encoded, err := multipartCodec{}.Marshal(parts)
+	if err != nil {
+		t.Fatalf("Marshal: %s", err)
+	}
+
+	body := string(encoded)
+	if !strings.Contains(body, `name="title"`) || !strings.Contains(body, "my file") {
+		t.Errorf("encoded body missing the title field: %s", body)
+	}
+	if !strings.Contains(body, "file contents") {
+		t.Errorf("encoded body missing the file part's contents: %s", body)
+	}
+}
+
+func TestMultipartCodecMarshalMissingFile(t *testing.T) {
+	parts := []MultipartPart{{Name: "file", BodyFile: "/no/such/file"}}
+	if _, err := (multipartCodec{}).Marshal(parts); err == nil {
+		t.Error("expected an error for a missing bodyFile")
+	}
+}
+
+func TestMultipartCodecUnmarshalUnsupported(t *testing.T) {
+	if _, err := (multipartCodec{}).Unmarshal([]byte("anything")); err == nil {
+		t.Error("expected Unmarshal to report multipart responses as unsupported")
+	}
+}
+
+func TestMsgpackCodecRoundTrip(t *testing.T) {
+	c := msgpackCodec{}
+	want := map[string]interface{}{"name": "bozr"}
+
+	encoded, err := c.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal: %s", err)
+	}
+
+	got, err := c.Unmarshal(encoded)
+	if err != nil {
+		t.Fatalf("Unmarshal: %s", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
+
+func TestCodecForIgnoresParams(t *testing.T) {
+	c, ok := codecFor("application/x-www-form-urlencoded; charset=utf-8")
+	if !ok {
+		t.Fatal("expected a codec for form content with a charset parameter")
+	}
+	if _, ok := c.(formCodec); !ok {
+		t.Errorf("codecFor returned %T, want formCodec", c)
+	}
+
+	if _, ok := codecFor("application/does-not-exist"); ok {
+		t.Error("expected no codec for an unregistered media type")
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/IharYudziankou/bozr/oasgen"
+)
+
+// OpenAPIExpectation checks a response against the schema and content-type
+// declared for an operation in an OpenAPI document, replacing a
+// BodySchemaFile/BodySchemaURI pair with a single "expect.openapi" block.
+type OpenAPIExpectation struct {
+	specFile    string
+	operationID string
+}
+
+func (e OpenAPIExpectation) check(resp Response) error {
+	validator, err := oasgen.NewValidator(e.specFile)
+	if err != nil {
+		return fmt.Errorf("openapi: %s", err.Error())
+	}
+
+	if req := resp.http.Request; req != nil && len(resp.reqBody) > 0 {
+		reqContentType := req.Header.Get("Content-Type")
+		if err := validator.ValidateRequestBody(e.operationID, reqContentType, resp.reqBody); err != nil {
+			return fmt.Errorf("openapi request body violation(s) for operation %q:\n%s", e.operationID, err.Error())
+		}
+	}
+
+	contentType := resp.http.Header.Get("Content-Type")
+	err = validator.ValidateResponse(e.operationID, resp.http.StatusCode, contentType, resp.body)
+	if err != nil {
+		return fmt.Errorf("openapi schema violation(s) for operation %q:\n%s", e.operationID, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PaesslerAG/jsonpath"
+	"github.com/google/uuid"
+)
+
+// exprPattern matches a {{ ... }} expression: a function name followed by
+// zero or more quoted/bare arguments, e.g. {{env "TOKEN"}}, {{uuid}},
+// {{now "RFC3339"}}.
+var exprPattern = regexp.MustCompile(`\{\{\s*(\w+)((?:\s+(?:"[^"]*"|\S+))*)\s*\}\}`)
+
+// legacyVarPattern matches the old literal {varName} placeholder, kept for
+// backward compatibility when a string has no {{...}} expressions in it.
+var legacyVarPattern = regexp.MustCompile(`\{([a-zA-Z_][a-zA-Z0-9_]*)\}`)
+
+// evalScope is the set of values an expression can read from: remembered
+// variables, the process environment, and the call's own "args".
+type evalScope struct {
+	remembered map[string]interface{}
+	args       map[string]interface{}
+}
+
+// populateVars substitutes every {{...}} expression and, for strings with
+// none, falls back to the legacy {var} syntax against rememberMap.
+func populateVars(str string, rememberMap map[string]interface{}, args map[string]string) string {
+	if !strings.Contains(str, "{{") {
+		return populateRememberedVars(str, rememberMap)
+	}
+
+	scope := evalScope{remembered: rememberMap, args: toInterfaceMap(args)}
+
+	return exprPattern.ReplaceAllStringFunc(str, func(match string) string {
+		parts := exprPattern.FindStringSubmatch(match)
+		name, rawArgs := parts[1], parseArgs(parts[2])
+
+		val, err := evalExpr(name, rawArgs, scope)
+		if err != nil {
+			debugMsg("Can't evaluate expression", match, ":", err)
+			return match
+		}
+
+		return fmt.Sprintf("%v", val)
+	})
+}
+
+func evalExpr(name string, args []string, scope evalScope) (interface{}, error) {
+	switch name {
+	case "env":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("env expects exactly one argument")
+		}
+		return os.Getenv(args[0]), nil
+
+	case "uuid":
+		return uuid.New().String(), nil
+
+	case "now":
+		layout := time.RFC3339
+		if len(args) == 1 {
+			layout = namedLayout(args[0])
+		}
+		return time.Now().Format(layout), nil
+
+	case "file":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("file expects exactly one argument")
+		}
+		return readVarFile(args[0])
+
+	case "jsonpath":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("jsonpath expects exactly one argument")
+		}
+		return jsonpath.Get(args[0], map[string]interface{}{
+			"remembered": scope.remembered,
+			"args":       scope.args,
+		})
+
+	default:
+		if v, ok := scope.args[name]; ok {
+			return v, nil
+		}
+		if v, ok := scope.remembered[name]; ok {
+			return v, nil
+		}
+		return nil, fmt.Errorf("unknown expression: %s", name)
+	}
+}
+
+// namedLayout resolves a handful of time.* layout constants by name, and
+// otherwise treats the argument as a literal Go time layout string, e.g.
+// {{now "2006-01-02"}}.
+func namedLayout(name string) string {
+	switch name {
+	case "RFC3339":
+		return time.RFC3339
+	case "RFC1123":
+		return time.RFC1123
+	case "Kitchen":
+		return time.Kitchen
+	default:
+		return name
+	}
+}
+
+// parseArgs splits the raw argument text of an expression into its
+// individual arguments, stripping quotes from quoted ones.
+func parseArgs(raw string) []string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+
+	var args []string
+	for _, a := range regexp.MustCompile(`"[^"]*"|\S+`).FindAllString(raw, -1) {
+		args = append(args, strings.Trim(a, `"`))
+	}
+	return args
+}
+
+func toInterfaceMap(m map[string]string) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func readVarFile(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\n"), nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEvalExprEnv(t *testing.T) {
+	os.Setenv("BOZR_EXPR_TEST", "hello")
+	defer os.Unsetenv("BOZR_EXPR_TEST")
+
+	got, err := evalExpr("env", []string{"BOZR_EXPR_TEST"}, evalScope{})
+	if err != nil {
+		t.Fatalf("evalExpr: %s", err)
+	}
+	if got != "hello" {
+		t.Errorf("evalExpr(env) = %v, want hello", got)
+	}
+}
+
+func TestEvalExprEnvRequiresOneArg(t *testing.T) {
+	if _, err := evalExpr("env", nil, evalScope{}); err == nil {
+		t.Error("expected an error when env is called without an argument")
+	}
+}
+
+func TestEvalExprUUID(t *testing.T) {
+	got, err := evalExpr("uuid", nil, evalScope{})
+	if err != nil {
+		t.Fatalf("evalExpr: %s", err)
+	}
+	if len(got.(string)) != 36 {
+		t.Errorf("evalExpr(uuid) = %q, want a 36-char UUID", got)
+	}
+}
+
+func TestEvalExprNow(t *testing.T) {
+	got, err := evalExpr("now", []string{"2006-01-02"}, evalScope{})
+	if err != nil {
+		t.Fatalf("evalExpr: %s", err)
+	}
+	if len(got.(string)) != len("2006-01-02") {
+		t.Errorf("evalExpr(now) = %q, want a date formatted as 2006-01-02", got)
+	}
+}
+
+func TestEvalExprFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token.txt")
+	if err := os.WriteFile(path, []byte("secret-token\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+
+	got, err := evalExpr("file", []string{path}, evalScope{})
+	if err != nil {
+		t.Fatalf("evalExpr: %s", err)
+	}
+	if got != "secret-token" {
+		t.Errorf("evalExpr(file) = %q, want %q (trailing newline trimmed)", got, "secret-token")
+	}
+}
+
+func TestEvalExprJSONPath(t *testing.T) {
+	scope := evalScope{remembered: map[string]interface{}{
+		"user": map[string]interface{}{"id": "u-1"},
+	}}
+
+	got, err := evalExpr("jsonpath", []string{"$.remembered.user.id"}, scope)
+	if err != nil {
+		t.Fatalf("evalExpr: %s", err)
+	}
+	if got != "u-1" {
+		t.Errorf("evalExpr(jsonpath) = %v, want u-1", got)
+	}
+}
+
+func TestEvalExprFallsBackToArgsThenRemembered(t *testing.T) {
+	scope := evalScope{
+		remembered: map[string]interface{}{"shared": "from-remembered", "onlyRemembered": "r"},
+		args:       map[string]interface{}{"shared": "from-args", "onlyArgs": "a"},
+	}
+
+	if got, _ := evalExpr("shared", nil, scope); got != "from-args" {
+		t.Errorf("args should take priority over remembered, got %v", got)
+	}
+	if got, _ := evalExpr("onlyArgs", nil, scope); got != "a" {
+		t.Errorf("evalExpr(onlyArgs) = %v, want a", got)
+	}
+	if got, _ := evalExpr("onlyRemembered", nil, scope); got != "r" {
+		t.Errorf("evalExpr(onlyRemembered) = %v, want r", got)
+	}
+	if _, err := evalExpr("unknownName", nil, scope); err == nil {
+		t.Error("expected an error for a name not found in args or remembered")
+	}
+}
+
+func TestPopulateVarsExpressions(t *testing.T) {
+	rememberMap := map[string]interface{}{"userId": "u-42"}
+	args := map[string]string{"token": "abc"}
+
+	got := populateVars("id={{userId}} token={{token}}", rememberMap, args)
+	want := "id=u-42 token=abc"
+	if got != want {
+		t.Errorf("populateVars = %q, want %q", got, want)
+	}
+}
+
+func TestPopulateVarsLeavesUnresolvableExpressionUntouched(t *testing.T) {
+	got := populateVars("{{doesNotExist}}", nil, nil)
+	if got != "{{doesNotExist}}" {
+		t.Errorf("populateVars = %q, want the expression left as-is", got)
+	}
+}
+
+func TestPopulateVarsFallsBackToLegacySyntax(t *testing.T) {
+	got := populateVars("id={userId}", map[string]interface{}{"userId": "u-42"}, nil)
+	if got != "id=u-42" {
+		t.Errorf("populateVars = %q, want id=u-42", got)
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want []string
+	}{
+		{"", nil},
+		{`"TOKEN"`, []string{"TOKEN"}},
+		{`"a" b`, []string{"a", "b"}},
+	}
+
+	for _, c := range cases {
+		got := parseArgs(c.raw)
+		if len(got) != len(c.want) {
+			t.Errorf("parseArgs(%q) = %v, want %v", c.raw, got, c.want)
+			continue
+		}
+		for i := range c.want {
+			if got[i] != c.want[i] {
+				t.Errorf("parseArgs(%q) = %v, want %v", c.raw, got, c.want)
+			}
+		}
+	}
+}
+
+func TestNamedLayout(t *testing.T) {
+	if got := namedLayout("RFC3339"); got != "2006-01-02T15:04:05Z07:00" {
+		t.Errorf("namedLayout(RFC3339) = %q", got)
+	}
+	if got := namedLayout("2006-01-02"); got != "2006-01-02" {
+		t.Errorf("namedLayout should pass unknown names through as a literal layout, got %q", got)
+	}
+}
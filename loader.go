@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/IharYudziankou/bozr/oasgen"
 	"github.com/xeipuuv/gojsonschema"
 )
 
@@ -191,6 +192,19 @@ func NewFileLoader(path string) <-chan TestSuite {
 	return channel
 }
 
+// NewOASDirLoader scaffolds suite files from every operation in the
+// OpenAPI document at specPath into workDir/generated, so they can be picked up by
+// the regular NewDirLoader/NewJSONTestCaseLoader the same way hand-written
+// suites are. It returns the paths written.
+func NewOASDirLoader(specPath string, workDir string) ([]string, error) {
+	gen, err := oasgen.NewGenerator(specPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return gen.Scaffold(workDir)
+}
+
 func isSuite(path string) bool {
 	schemaLoader := gojsonschema.NewStringLoader(suiteShapeSchema)
 
@@ -316,6 +330,41 @@ const suiteDetailedSchema = `
 								},
 								"absent": {
 								  "type" : "array"
+								},
+								"openapi": {
+									"type": "object",
+									"properties": {
+										"file": {
+											"type": "string"
+										},
+										"operationId": {
+											"type": "string"
+										}
+									},
+									"required": [
+										"file",
+										"operationId"
+									]
+								},
+								"events": {
+									"type": "array",
+									"items": {
+										"type": "object",
+										"properties": {
+											"event": {
+												"type": "string"
+											},
+											"data": {
+												"type": "string"
+											},
+											"path-expectations": {
+												"type": "object"
+											}
+										}
+									}
+								},
+								"eventsAsSet": {
+									"type": "boolean"
 								}
 							},
 							"additionalProperties": false
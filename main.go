@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -10,10 +11,12 @@ import (
 	"mime"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/PaesslerAG/jsonpath"
 	"github.com/clbanning/mxj"
 )
 
@@ -30,6 +33,10 @@ func init() {
 		h += "  -d, --debug		Enable debug mode\n"
 		h += "  -H, --host		Server to test\n"
 		h += "  -h, --help		Print usage\n"
+		h += "  -p		Number of suites to run concurrently (default 1)\n"
+		h += "  --timeout	Global HTTP client timeout, e.g. 30s\n"
+		h += "  --report-template	Directory with custom HTML report templates\n"
+		h += "  --oas		Scaffold suites from an OpenAPI 3 document into <DIR>/generated before running (Swagger 2 is not supported)\n"
 		h += "  -v, --version		Print version information and quit\n\n"
 
 		h += "Examples:\n"
@@ -41,11 +48,15 @@ func init() {
 }
 
 var (
-	suiteDir    string
-	hostFlag    string
-	debugFlag   bool
-	helpFlag    bool
-	versionFlag bool
+	suiteDir       string
+	hostFlag       string
+	debugFlag      bool
+	helpFlag       bool
+	versionFlag    bool
+	parallelism    int
+	httpTimeout    time.Duration
+	reportTemplate string
+	oasSpec        string
 )
 
 func main() {
@@ -60,6 +71,14 @@ func main() {
 	flag.BoolVar(&versionFlag, "v", false, "Print version information and quit")
 	flag.BoolVar(&versionFlag, "version", false, "Print version information and quit")
 
+	flag.IntVar(&parallelism, "p", 1, "Number of suites to run concurrently")
+
+	flag.DurationVar(&httpTimeout, "timeout", 0, "Global HTTP client timeout, e.g. 30s (default: no timeout)")
+
+	flag.StringVar(&reportTemplate, "report-template", "", "Directory with custom index.html.tmpl/case.html.tmpl for the HTML report")
+
+	flag.StringVar(&oasSpec, "oas", "", "Scaffold suites from an OpenAPI 3 document into <DIR>/generated before running (Swagger 2 is not supported)")
+
 	flag.Parse()
 
 	if versionFlag {
@@ -80,6 +99,13 @@ func main() {
 		return
 	}
 
+	if oasSpec != "" {
+		if _, err := NewOASDirLoader(oasSpec, suiteDir); err != nil {
+			fmt.Printf("Error scaffolding suites from %s: %v\n", oasSpec, err)
+			os.Exit(1)
+		}
+	}
+
 	loader := NewJSONTestCaseLoader(suiteDir)
 	suits, err := loader.Load()
 	if err != nil {
@@ -88,26 +114,25 @@ func main() {
 	}
 
 	path, _ := filepath.Abs("./report")
-	reporter := NewMultiReporter(NewJUnitReporter(path), NewConsoleReporter())
-
-	// test case runner?
-	for _, suite := range suits {
-		for _, testCase := range suite.Cases {
+	reporter := NewMultiReporter(NewJUnitReporter(path), NewConsoleReporter(), NewHTMLReporter(path, reportTemplate))
 
-			rememberedMap := make(map[string]interface{})
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-			for _, c := range testCase.Calls {
-				tr := call(suite, testCase, c, rememberedMap)
-				tr.Suite = suite
-				reporter.Report(*tr)
-			}
+	suiteChan := make(chan TestSuite)
+	go func() {
+		defer close(suiteChan)
+		for _, suite := range suits {
+			suiteChan <- suite
 		}
-	}
+	}()
+
+	runSuites(ctx, suiteChan, parallelism, reporter)
 
 	reporter.Flush()
 }
 
-func call(testSuite TestSuite, testCase TestCase, call Call, rememberMap map[string]interface{}) (result *TestResult) {
+func call(ctx context.Context, testSuite TestSuite, testCase TestCase, call Call, rememberMap map[string]interface{}) (result *TestResult) {
 	debugMsg("--- Starting call ...") // TODO add call description
 	start := time.Now()
 	result = &TestResult{Case: testCase}
@@ -130,50 +155,101 @@ func call(testSuite TestSuite, testCase TestCase, call Call, rememberMap map[str
 		}
 	}
 
-	req := populateRequest(on, string(dat), rememberMap)
-	debugMsg("Request: ", req)
+	result.ReqBody = encodeBody(on, populateVars(string(dat), rememberMap, call.Args), testSuite.Dir)
 
-	client := &http.Client{}
+	client := &http.Client{Timeout: httpTimeout}
 
-	resp, err := client.Do(req)
+	protoFile := on.ProtoFile
+	if protoFile != "" {
+		if uri, pathErr := toAbsPath(testSuite.Dir, protoFile); pathErr == nil {
+			protoFile = uri
+		}
+	}
 
+	exps, err := expectations(call, testSuite.Dir)
 	if err != nil {
-		debugMsg("Error when sending request", err)
 		result.Cause = err
 		return
 	}
 
-	defer resp.Body.Close()
+	reader := responseReaderFor(on)
+
+	var (
+		resp      *http.Response
+		testResp  Response
+		expectErr error
+	)
+
+	attempts := call.Retry.maxAttempts()
+	for attempt := 1; attempt <= attempts; attempt++ {
+		attemptStart := time.Now()
+
+		req := populateRequest(on, string(dat), rememberMap, call.Args, testSuite.Dir)
+		req = req.WithContext(requestContext(ctx, on.Timeout))
+		debugMsg("Request: ", req)
+
+		var (
+			body   []byte
+			events []StreamEvent
+		)
+		resp, body, events, err = doAttempt(client, req, reader)
+
+		statusCode := 0
+		expectErr = nil
+		if err == nil {
+			testResp = Response{
+				http:         *resp,
+				body:         body,
+				reqBody:      result.ReqBody,
+				protoFile:    protoFile,
+				protoMessage: on.ProtoMessage,
+			}
+			statusCode = resp.StatusCode
+			expectErr = checkExpectations(exps, testResp, call, events)
+		}
+
+		result.Attempts = append(result.Attempts, AttemptResult{
+			Number:   attempt,
+			Duration: time.Since(attemptStart),
+			Status:   statusCode,
+			Err:      err,
+		})
+
+		if !call.Retry.shouldRetry(statusCode, err, expectErr) || attempt == attempts {
+			break
+		}
+
+		debugMsg("Retrying call, attempt", attempt+1, "of", attempts)
+		time.Sleep(call.Retry.backoff(attempt))
+	}
 
-	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		debugMsg("Error reading response")
+		debugMsg("Error when sending request", err)
 		result.Cause = err
 		return
 	}
 
-	debugMsg("Resp: ", string(body))
+	debugMsg("Resp: ", string(testResp.body))
 	end := time.Now()
 
-	testResp := Response{http: *resp, body: body}
 	result.Resp = testResp
 	result.Duration = end.Sub(start)
 
-	exps, err := expectations(call, testSuite.Dir)
-	if err != nil {
-		result.Cause = err
+	if expectErr != nil {
+		result.Cause = expectErr
 		return
 	}
 
-	for _, exp := range exps {
-		checkErr := exp.check(testResp)
-		if checkErr != nil {
-			result.Cause = checkErr
-			return
+	var m map[string]interface{}
+	if on.Stream != StreamNone {
+		// A streamed body is the last event's data, whatever the response's
+		// own Content-Type; non-JSON data leaves nothing to remember.
+		if json.Unmarshal(testResp.body, &m) != nil {
+			m = nil
 		}
+	} else {
+		m, err = testResp.bodyAsMap()
 	}
-
-	m, err := testResp.bodyAsMap()
 	if err != nil {
 		debugMsg("Can't parse response body to Map for [remember]")
 		result.Cause = err
@@ -191,22 +267,53 @@ func call(testSuite TestSuite, testCase TestCase, call Call, rememberMap map[str
 	return result
 }
 
-func populateRequest(on On, body string, rememberMap map[string]interface{}) *http.Request {
+// doAttempt sends req and reads the response body through reader, so a
+// retry (if any) can be decided on both the network error and the status
+// code. reader is BufferedReader unless the call opted into streaming.
+func doAttempt(client *http.Client, req *http.Request, reader ResponseReader) (*http.Response, []byte, []StreamEvent, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, events, err := reader.Read(resp)
+	if err != nil {
+		return resp, nil, nil, err
+	}
+
+	return resp, body, events, nil
+}
+
+// requestContext applies on.timeout as a per-call deadline on top of ctx,
+// when one is given; otherwise ctx is used as-is. The global --timeout is
+// not part of ctx: it is set as the http.Client's own Timeout.
+func requestContext(ctx context.Context, timeout string) context.Context {
+	d, err := time.ParseDuration(timeout)
+	if err != nil || d <= 0 {
+		return ctx
+	}
+
+	ctx, _ = context.WithTimeout(ctx, d)
+	return ctx
+}
+
+func populateRequest(on On, body string, rememberMap map[string]interface{}, args map[string]string, srcDir string) *http.Request {
 
 	url := urlPrefix(on.URL)
 
-	body = populateRememberedVars(body, rememberMap)
-	dat := []byte(body)
+	body = populateVars(body, rememberMap, args)
+	dat := encodeBody(on, body, srcDir)
 
 	req, _ := http.NewRequest(on.Method, url, bytes.NewBuffer(dat))
 
 	for key, value := range on.Headers {
-		req.Header.Add(key, populateRememberedVars(value, rememberMap))
+		req.Header.Add(key, populateVars(value, rememberMap, args))
 	}
 
 	q := req.URL.Query()
 	for key, value := range on.Params {
-		q.Add(key, populateRememberedVars(value, rememberMap))
+		q.Add(key, populateVars(value, rememberMap, args))
 	}
 	req.URL.RawQuery = q.Encode()
 
@@ -230,6 +337,26 @@ func populateRememberedVars(str string, rememberMap map[string]interface{}) stri
 	return res
 }
 
+// checkExpectations runs every expectation (and, for a streaming call, the
+// expect.events check) against one attempt's response, so retry.retryOn can
+// react to an "expect" failure the same way it reacts to a status code or
+// network error.
+func checkExpectations(exps []ResponseExpectation, testResp Response, call Call, events []StreamEvent) error {
+	for _, exp := range exps {
+		if checkErr := exp.check(testResp); checkErr != nil {
+			return checkErr
+		}
+	}
+
+	if call.On.Stream != StreamNone && len(call.Expect.Events) > 0 {
+		if checkErr := checkEvents(call.Expect.Events, events, call.Expect.EventsAsSet); checkErr != nil {
+			return checkErr
+		}
+	}
+
+	return nil
+}
+
 func expectations(call Call, srcDir string) ([]ResponseExpectation, error) {
 	var exps []ResponseExpectation
 	if call.Expect.StatusCode != 0 {
@@ -262,6 +389,17 @@ func expectations(call Call, srcDir string) ([]ResponseExpectation, error) {
 		exps = append(exps, BodySchemaExpectation{schemaURI: schemeURI})
 	}
 
+	if call.Expect.OpenAPI != nil {
+		specURI, err := toAbsPath(srcDir, call.Expect.OpenAPI.File)
+		if err != nil {
+			return nil, err
+		}
+		exps = append(exps, OpenAPIExpectation{
+			specFile:    specURI,
+			operationID: call.Expect.OpenAPI.OperationID,
+		})
+	}
+
 	if len(call.Expect.Body) > 0 {
 		exps = append(exps, BodyExpectation{pathExpectations: call.Expect.Body})
 	}
@@ -298,20 +436,30 @@ func remember(bodyMap map[string]interface{}, remember map[string]string, rememb
 
 	for varName, path := range remember {
 
-		splitPath := strings.Split(path, ".")
-
-		if rememberVar, err := getByPath(bodyMap, splitPath...); err == nil {
+		rememberVar, rememberErr := rememberByPath(bodyMap, path)
+		if rememberErr == nil {
 			rememberedMap[varName] = rememberVar
 		} else {
 			strErr := fmt.Sprintf("Remembered value not found, path: %v", path)
 			err = errors.New(strErr)
 		}
-		//fmt.Printf("v: %v\n", getByPath(bodyMap, b...))
 	}
 
 	return err
 }
 
+// rememberByPath resolves path against bodyMap. A path starting with "$"
+// is evaluated as full JSONPath (so array elements and filtered subtrees
+// can be captured); anything else keeps the legacy dotted-path behaviour.
+func rememberByPath(bodyMap map[string]interface{}, path string) (interface{}, error) {
+	if strings.HasPrefix(path, "$") {
+		return jsonpath.Get(path, bodyMap)
+	}
+
+	splitPath := strings.Split(path, ".")
+	return getByPath(bodyMap, splitPath...)
+}
+
 func (e Response) bodyAsMap() (map[string]interface{}, error) {
 	var bodyMap map[string]interface{}
 	var err error
@@ -328,6 +476,16 @@ func (e Response) bodyAsMap() (map[string]interface{}, error) {
 		err = json.Unmarshal(e.body, &bodyMap)
 	}
 
+	if bodyMap == nil && err == nil && contentType == "application/x-protobuf" {
+		bodyMap, err = UnmarshalWithDescriptor(e.protoFile, e.protoMessage, e.body)
+	}
+
+	if bodyMap == nil && err == nil {
+		if c, ok := codecFor(contentType); ok {
+			bodyMap, err = c.Unmarshal(e.body)
+		}
+	}
+
 	return bodyMap, err
 }
 
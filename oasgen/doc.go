@@ -0,0 +1,11 @@
+// Package oasgen generates bozr test suites from an OpenAPI 3 document and
+// validates live HTTP responses against the schemas declared in that
+// document. Swagger 2 documents are not converted and fail to load.
+//
+// Scaffold reads every operation in the spec and produces one bozr Call
+// per operation, pre-filled with the method, URL, path/query parameters
+// and an example request body taken from components/examples when one is
+// present. Validator checks a response body and content-type against the
+// response schema declared for a given operationId, aggregating every
+// violation instead of stopping at the first one.
+package oasgen
@@ -0,0 +1,200 @@
+package oasgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Generator scaffolds bozr suite files from a single OpenAPI document.
+type Generator struct {
+	// SpecPath is the path to the OpenAPI 3 document (json or yaml). Swagger 2
+	// documents are not supported.
+	SpecPath string
+
+	doc *openapi3.T
+}
+
+// NewGenerator loads and validates the document at specPath.
+func NewGenerator(specPath string) (*Generator, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromFile(specPath)
+	if err != nil {
+		return nil, fmt.Errorf("can't load OpenAPI document: %s", err.Error())
+	}
+
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %s", err.Error())
+	}
+
+	return &Generator{SpecPath: specPath, doc: doc}, nil
+}
+
+// rawCall is the JSON shape of a single "calls" entry, kept independent of
+// the main package's Call/On/Expect types so this package stays free of an
+// import cycle. Generated files are read back through the normal suite
+// loader, so the shape has to match what that loader expects.
+type rawCall struct {
+	Args   map[string]interface{} `json:"args,omitempty"`
+	On     rawOn                  `json:"on"`
+	Expect rawExpect              `json:"expect"`
+}
+
+type rawOn struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Params  map[string]string `json:"params,omitempty"`
+	Body    interface{}       `json:"body,omitempty"`
+}
+
+type rawExpect struct {
+	StatusCode int               `json:"statusCode,omitempty"`
+	OpenAPI    *rawOpenAPIExpect `json:"openapi,omitempty"`
+}
+
+type rawOpenAPIExpect struct {
+	File        string `json:"file"`
+	OperationID string `json:"operationId"`
+}
+
+type rawSuiteCase struct {
+	Name  string    `json:"name"`
+	Calls []rawCall `json:"calls"`
+}
+
+// generatedSuiteDir is the subdirectory Scaffold writes into, so generated
+// suites never collide with (and silently overwrite) a hand-written suite
+// file that happens to sanitize to the same name.
+const generatedSuiteDir = "generated"
+
+// Scaffold writes one suite file per OpenAPI tag (falling back to
+// "default") under outDir/generated, with one call per operation in that
+// tag. Suites already under that subdirectory from a previous run are
+// overwritten, since they're Scaffold's own output; anything else under
+// outDir is left untouched.
+func (g *Generator) Scaffold(outDir string) ([]string, error) {
+	byTag := map[string][]rawCall{}
+
+	for path, item := range g.doc.Paths.Map() {
+		for method, op := range item.Operations() {
+			tag := "default"
+			if len(op.Tags) > 0 {
+				tag = op.Tags[0]
+			}
+			byTag[tag] = append(byTag[tag], g.operationCall(path, method, op))
+		}
+	}
+
+	genDir := filepath.Join(outDir, generatedSuiteDir)
+	if err := os.MkdirAll(genDir, 0755); err != nil {
+		return nil, fmt.Errorf("can't create %s: %s", genDir, err.Error())
+	}
+
+	var written []string
+	for tag, calls := range byTag {
+		suite := []rawSuiteCase{{Name: tag, Calls: calls}}
+
+		data, err := json.MarshalIndent(suite, "", "  ")
+		if err != nil {
+			return written, err
+		}
+
+		file := filepath.Join(genDir, sanitize(tag)+".json")
+		if err := ioutil.WriteFile(file, data, 0644); err != nil {
+			return written, err
+		}
+		written = append(written, file)
+	}
+
+	return written, nil
+}
+
+func (g *Generator) operationCall(path, method string, op *openapi3.Operation) rawCall {
+	on := rawOn{
+		Method:  strings.ToUpper(method),
+		URL:     path,
+		Headers: map[string]string{},
+		Params:  map[string]string{},
+	}
+
+	for _, p := range op.Parameters {
+		param := p.Value
+		placeholder := "{" + param.Name + "}"
+
+		switch param.In {
+		case "path":
+			// The OpenAPI path template already uses bozr's own
+			// "{var}" placeholder syntax, so it only needs rewriting
+			// when the parameter has an example to fill in with.
+			if example := paramExample(param); example != "" {
+				on.URL = strings.Replace(on.URL, "{"+param.Name+"}", example, 1)
+			}
+		case "query":
+			on.Params[param.Name] = placeholder
+		case "header":
+			on.Headers[param.Name] = placeholder
+		}
+	}
+
+	if op.RequestBody != nil {
+		on.Body = firstExample(op.RequestBody.Value)
+	}
+
+	expect := rawExpect{StatusCode: 200}
+	if op.OperationID != "" {
+		expect.OpenAPI = &rawOpenAPIExpect{File: g.SpecPath, OperationID: op.OperationID}
+	}
+
+	return rawCall{On: on, Expect: expect}
+}
+
+// firstExample returns the inline example of the request body's JSON media
+// type if it has one, otherwise the value of one of its named examples; which
+// one is not defined when there are several.
+func firstExample(body *openapi3.RequestBody) interface{} {
+	media := body.Content.Get("application/json")
+	if media == nil {
+		return nil
+	}
+
+	if media.Example != nil {
+		return media.Example
+	}
+
+	for _, ex := range media.Examples {
+		if ex.Value != nil {
+			return ex.Value.Value
+		}
+	}
+
+	return nil
+}
+
+// paramExample renders a path parameter's example value, if any, as a
+// string suitable for splicing straight into a URL.
+func paramExample(param *openapi3.Parameter) string {
+	if param.Example != nil {
+		return fmt.Sprintf("%v", param.Example)
+	}
+
+	if param.Schema != nil && param.Schema.Value != nil && param.Schema.Value.Example != nil {
+		return fmt.Sprintf("%v", param.Schema.Value.Example)
+	}
+
+	return ""
+}
+
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,129 @@
+package oasgen
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// ValidationErrors aggregates every schema/content-type violation found
+// for a single response, instead of stopping at the first one.
+type ValidationErrors []error
+
+func (ve ValidationErrors) Error() string {
+	msgs := make([]string, len(ve))
+	for i, e := range ve {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Validator checks HTTP responses against the schema declared for a given
+// operationId in an OpenAPI document.
+type Validator struct {
+	doc *openapi3.T
+}
+
+// NewValidator builds a Validator for the document at specPath.
+func NewValidator(specPath string) (*Validator, error) {
+	g, err := NewGenerator(specPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Validator{doc: g.doc}, nil
+}
+
+// ValidateResponse checks status, content-type and body against the
+// response schema declared for operationID, enforcing readOnly/writeOnly
+// property rules, and returns every violation found.
+func (v *Validator) ValidateResponse(operationID string, statusCode int, contentType string, body []byte) error {
+	route, err := v.routeFor(operationID)
+	if err != nil {
+		return ValidationErrors{err}
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{Route: route},
+		Status:                 statusCode,
+		Header:                 map[string][]string{"Content-Type": {mediaType}},
+		Options:                &openapi3filter.Options{MultiError: true},
+	}
+	input.SetBodyBytes(body)
+
+	if err := openapi3filter.ValidateResponse(nil, input); err != nil {
+		return flatten(err)
+	}
+
+	return nil
+}
+
+// ValidateRequestBody checks a request body against the schema declared
+// for operationID before it is sent, enforcing writeOnly/readOnly
+// property rules the same way ValidateResponse does, and returns every
+// violation found rather than stopping at the first one.
+func (v *Validator) ValidateRequestBody(operationID, contentType string, body []byte) error {
+	route, err := v.routeFor(operationID)
+	if err != nil {
+		return ValidationErrors{err}
+	}
+
+	if route.Operation.RequestBody == nil {
+		return nil
+	}
+
+	input := &openapi3filter.RequestValidationInput{
+		Route:   route,
+		Options: &openapi3filter.Options{MultiError: true},
+	}
+	input.SetBodyBytes(body, contentType)
+
+	if err := openapi3filter.ValidateRequestBody(nil, input, route.Operation.RequestBody.Value); err != nil {
+		return flatten(err)
+	}
+
+	return nil
+}
+
+// routeFor builds a routers.Route for operationID by hand from the
+// document, rather than resolving one from an actual URL - the call site
+// only has an operationId to go on, not a matched request path.
+func (v *Validator) routeFor(operationID string) (*routers.Route, error) {
+	for path, item := range v.doc.Paths.Map() {
+		for method, op := range item.Operations() {
+			if op.OperationID != operationID {
+				continue
+			}
+
+			return &routers.Route{
+				Spec:      v.doc,
+				Path:      path,
+				PathItem:  item,
+				Method:    method,
+				Operation: op,
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown operationId: %s", operationID)
+}
+
+func flatten(err error) ValidationErrors {
+	if me, ok := err.(openapi3.MultiError); ok {
+		var out ValidationErrors
+		for _, e := range me {
+			out = append(out, flatten(e)...)
+		}
+		return out
+	}
+	return ValidationErrors{err}
+}
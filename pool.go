@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// defaultParallelism is used when -p is not given or is <= 0.
+const defaultParallelism = 1
+
+// runSuites drains suites from in, running up to parallelism suites at a
+// time. Calls that belong to the same TestCase always run sequentially in
+// the order they're declared, since they share a rememberedMap - only
+// different cases (and different suites) run concurrently.
+//
+// A suite can opt out of running alongside others by setting
+// "parallel": false, in which case it is run on its own, after every
+// in-flight suite has finished. ctx cancellation (global timeout or
+// Ctrl-C) stops in-flight HTTP calls and prevents new suites from
+// starting; suites already running are given a chance to unwind.
+func runSuites(ctx context.Context, in <-chan TestSuite, parallelism int, reporter *MultiReporter) {
+	if parallelism < 1 {
+		parallelism = defaultParallelism
+	}
+
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+
+	for suite := range in {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if !suite.runsInParallel() {
+			wg.Wait()
+			runSuite(ctx, suite, reporter)
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(s TestSuite) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			runSuite(ctx, s, reporter)
+		}(suite)
+	}
+
+	wg.Wait()
+}
+
+// runSuite runs every case of suite, honouring per-case "parallel"/
+// "parallelism" overrides. Calls within a single case always run in
+// order on the same goroutine.
+func runSuite(ctx context.Context, suite TestSuite, reporter *MultiReporter) {
+	casesParallelism := suite.parallelism()
+
+	sem := make(chan struct{}, casesParallelism)
+	var wg sync.WaitGroup
+
+	for _, testCase := range suite.Cases {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if casesParallelism <= 1 || !testCase.runsInParallel() {
+			wg.Wait()
+			runCase(ctx, suite, testCase, reporter)
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(tc TestCase) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			runCase(ctx, suite, tc, reporter)
+		}(testCase)
+	}
+
+	wg.Wait()
+}
+
+func runCase(ctx context.Context, suite TestSuite, testCase TestCase, reporter *MultiReporter) {
+	rememberedMap := make(map[string]interface{})
+
+	for _, c := range testCase.Calls {
+		if ctx.Err() != nil {
+			return
+		}
+
+		tr := call(ctx, suite, testCase, c, rememberedMap)
+		tr.Suite = suite
+		reporter.Report(*tr)
+	}
+}
+
+// runsInParallel reports whether suite is allowed to run alongside other
+// suites. Suites default to running in parallel unless "parallel": false
+// is set explicitly.
+func (s TestSuite) runsInParallel() bool {
+	return s.Parallel == nil || *s.Parallel
+}
+
+// parallelism returns how many cases of the suite may run at once,
+// defaulting to 1 (sequential) when the suite doesn't declare otherwise.
+func (s TestSuite) parallelism() int {
+	if s.Parallelism > 0 {
+		return s.Parallelism
+	}
+	return defaultParallelism
+}
+
+func (tc TestCase) runsInParallel() bool {
+	return tc.Parallel == nil || *tc.Parallel
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeReporter collects results under a mutex so tests can assert on them
+// after a run, regardless of how many goroutines called Report.
+type fakeReporter struct {
+	mu      sync.Mutex
+	results []TestResult
+}
+
+func (f *fakeReporter) Report(result TestResult) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.results = append(f.results, result)
+}
+
+func (f *fakeReporter) Flush() {}
+
+func (f *fakeReporter) len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.results)
+}
+
+// suiteHittingServer builds a single-call, single-case suite that GETs url.
+func suiteHittingServer(name, url string, parallel *bool) TestSuite {
+	return TestSuite{
+		Name:     name,
+		Parallel: parallel,
+		Cases: []TestCase{{
+			Name:  name,
+			Calls: []Call{{On: On{Method: "GET", URL: url}}},
+		}},
+	}
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestRunSuitesRespectsParallelismLimit(t *testing.T) {
+	var active, maxActive int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		for {
+			m := atomic.LoadInt32(&maxActive)
+			if n <= m || atomic.CompareAndSwapInt32(&maxActive, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&active, -1)
+	}))
+	defer srv.Close()
+
+	const parallelism = 2
+	const suiteCount = 6
+
+	in := make(chan TestSuite)
+	reporter := &fakeReporter{}
+	done := make(chan struct{})
+
+	go func() {
+		runSuites(context.Background(), in, parallelism, NewMultiReporter(reporter))
+		close(done)
+	}()
+
+	for i := 0; i < suiteCount; i++ {
+		in <- suiteHittingServer(fmt.Sprintf("suite-%d", i), srv.URL, nil)
+	}
+	close(in)
+	<-done
+
+	if reporter.len() != suiteCount {
+		t.Fatalf("got %d reported cases, want %d", reporter.len(), suiteCount)
+	}
+	if got := atomic.LoadInt32(&maxActive); got > parallelism {
+		t.Errorf("observed %d concurrent suites, want at most %d", got, parallelism)
+	}
+}
+
+func TestRunSuiteOptOutRunsAlone(t *testing.T) {
+	var active, maxDuringOptOut int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&active, 1)
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&active, -1)
+	}))
+	defer srv.Close()
+
+	optOutSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.StoreInt32(&maxDuringOptOut, atomic.LoadInt32(&active))
+	}))
+	defer optOutSrv.Close()
+
+	in := make(chan TestSuite)
+	reporter := &fakeReporter{}
+	done := make(chan struct{})
+
+	go func() {
+		runSuites(context.Background(), in, 4, NewMultiReporter(reporter))
+		close(done)
+	}()
+
+	for i := 0; i < 3; i++ {
+		in <- suiteHittingServer(fmt.Sprintf("parallel-%d", i), srv.URL, nil)
+	}
+	in <- suiteHittingServer("opt-out", optOutSrv.URL, boolPtr(false))
+	close(in)
+	<-done
+
+	if reporter.len() != 4 {
+		t.Fatalf("got %d reported cases, want 4", reporter.len())
+	}
+	if got := atomic.LoadInt32(&maxDuringOptOut); got != 0 {
+		t.Errorf("opt-out suite ran alongside %d other suites, want 0", got)
+	}
+}
+
+func TestRunSuitesStopsOnContextCancellation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	in := make(chan TestSuite, 3)
+	for i := 0; i < 3; i++ {
+		in <- suiteHittingServer(fmt.Sprintf("suite-%d", i), srv.URL, nil)
+	}
+	close(in)
+
+	reporter := &fakeReporter{}
+
+	done := make(chan struct{})
+	go func() {
+		runSuites(ctx, in, 2, NewMultiReporter(reporter))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("runSuites didn't return promptly after ctx was already cancelled")
+	}
+
+	if got := reporter.len(); got != 0 {
+		t.Errorf("got %d reported cases after cancellation, want 0", got)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Reporter is notified of every TestResult as it completes and flushed
+// once the run is done. Suites (and, within a suite, cases) may now run
+// concurrently, so every Reporter implementation must be safe to call
+// Report from multiple goroutines at once.
+type Reporter interface {
+	Report(result TestResult)
+	Flush()
+}
+
+// MultiReporter fans a TestResult out to every configured Reporter. It
+// adds no synchronization of its own - each Reporter is responsible for
+// being goroutine-safe on its own Report/Flush.
+type MultiReporter struct {
+	reporters []Reporter
+}
+
+// NewMultiReporter builds a MultiReporter over the given reporters.
+func NewMultiReporter(reporters ...Reporter) *MultiReporter {
+	return &MultiReporter{reporters: reporters}
+}
+
+// Report forwards result to every reporter.
+func (m *MultiReporter) Report(result TestResult) {
+	for _, r := range m.reporters {
+		r.Report(result)
+	}
+}
+
+// Flush flushes every reporter.
+func (m *MultiReporter) Flush() {
+	for _, r := range m.reporters {
+		r.Flush()
+	}
+}
+
+// ConsoleReporter prints one line per call. Output is buffered per suite
+// and only written out on Flush, suite by suite, so concurrent suites don't
+// interleave their lines on stdout.
+type ConsoleReporter struct {
+	mu     sync.Mutex
+	suites map[string]*consoleSuite
+	order  []string
+}
+
+type consoleSuite struct {
+	lines []string
+}
+
+// NewConsoleReporter creates a ConsoleReporter.
+func NewConsoleReporter() *ConsoleReporter {
+	return &ConsoleReporter{suites: map[string]*consoleSuite{}}
+}
+
+// Report buffers one result's line under its suite.
+func (c *ConsoleReporter) Report(result TestResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name := result.Suite.Name
+	s, ok := c.suites[name]
+	if !ok {
+		s = &consoleSuite{}
+		c.suites[name] = s
+		c.order = append(c.order, name)
+	}
+
+	status := "PASS"
+	detail := ""
+	if result.Cause != nil {
+		status = "FAIL"
+		detail = ": " + result.Cause.Error()
+	}
+
+	s.lines = append(s.lines, "  ["+status+"] "+result.Case.Name+detail)
+}
+
+// Flush prints every suite's buffered lines, suite by suite, in the
+// order each suite's first result arrived.
+func (c *ConsoleReporter) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, name := range c.order {
+		s := c.suites[name]
+		fmt.Println(name)
+		for _, line := range s.lines {
+			fmt.Println(line)
+		}
+	}
+}
+
+// JUnitReporter aggregates TestResults into one JUnit XML file per suite,
+// written under OutDir on Flush.
+type JUnitReporter struct {
+	OutDir string
+
+	mu     sync.Mutex
+	suites map[string]*junitSuite
+	order  []string
+}
+
+type junitSuite struct {
+	Name   string
+	Cases  []junitCase
+	Passed int
+	Failed int
+}
+
+type junitCase struct {
+	Name     string
+	Passed   bool
+	Failure  string
+	Duration float64
+}
+
+// NewJUnitReporter creates a JUnitReporter writing XML files under
+// outDir.
+func NewJUnitReporter(outDir string) *JUnitReporter {
+	return &JUnitReporter{OutDir: outDir, suites: map[string]*junitSuite{}}
+}
+
+// Report aggregates result under its suite, grouping JUnit output by
+// suite the same way it did before concurrent suites existed.
+func (j *JUnitReporter) Report(result TestResult) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	name := result.Suite.Name
+	s, ok := j.suites[name]
+	if !ok {
+		s = &junitSuite{Name: name}
+		j.suites[name] = s
+		j.order = append(j.order, name)
+	}
+
+	passed := result.Cause == nil
+	if passed {
+		s.Passed++
+	} else {
+		s.Failed++
+	}
+
+	c := junitCase{
+		Name:     result.Case.Name,
+		Passed:   passed,
+		Duration: result.Duration.Seconds(),
+	}
+	if !passed {
+		c.Failure = result.Cause.Error()
+	}
+
+	s.Cases = append(s.Cases, c)
+}
+
+// Flush writes one XML file per suite to OutDir.
+func (j *JUnitReporter) Flush() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, name := range j.order {
+		writeJUnitXML(j.OutDir, j.suites[name])
+	}
+}
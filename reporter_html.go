@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultReportTemplateDir is the directory, relative to the working
+// directory, that holds the default HTML templates, used unless
+// --report-template overrides it. The templates are read from disk on
+// Flush; they are not embedded in the binary.
+const defaultReportTemplateDir = "report/html/templates"
+
+// HTMLReporter writes a browsable static site under its OutDir: an index
+// page listing every suite with pass/fail counts and duration, and one
+// page per case with the full request/response and the failed
+// expectation, if any.
+type HTMLReporter struct {
+	OutDir      string
+	TemplateDir string
+
+	mu     sync.Mutex
+	suites map[string]*htmlSuite
+	order  []string
+}
+
+type htmlSuite struct {
+	Name     string
+	Dir      string
+	Cases    []htmlCase
+	Passed   int
+	Failed   int
+	Duration time.Duration
+}
+
+type htmlCase struct {
+	Name              string
+	File              string
+	Passed            bool
+	Duration          time.Duration
+	Request           htmlRequest
+	Response          htmlResponse
+	FailedExpectation string
+}
+
+type htmlRequest struct {
+	Method  string
+	URL     string
+	Headers map[string]string
+	Body    string
+}
+
+type htmlResponse struct {
+	StatusCode int
+	Headers    map[string][]string
+	Body       string
+}
+
+// NewHTMLReporter creates a reporter writing under outDir/html, using
+// templateDir for index.html.tmpl/case.html.tmpl, or
+// defaultReportTemplateDir when templateDir is empty.
+func NewHTMLReporter(outDir string, templateDir string) *HTMLReporter {
+	if templateDir == "" {
+		templateDir = defaultReportTemplateDir
+	}
+
+	return &HTMLReporter{
+		OutDir:      filepath.Join(outDir, "html"),
+		TemplateDir: templateDir,
+		suites:      map[string]*htmlSuite{},
+	}
+}
+
+// Report records one TestResult. Safe for concurrent use, since suites
+// may now run concurrently.
+func (r *HTMLReporter) Report(result TestResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := result.Suite.Name
+	s, ok := r.suites[name]
+	if !ok {
+		s = &htmlSuite{Name: name, Dir: result.Suite.Dir}
+		r.suites[name] = s
+		r.order = append(r.order, name)
+	}
+
+	passed := result.Cause == nil
+	if passed {
+		s.Passed++
+	} else {
+		s.Failed++
+	}
+	s.Duration += result.Duration
+
+	c := htmlCase{
+		Name:     result.Case.Name,
+		Passed:   passed,
+		Duration: result.Duration,
+		Request: htmlRequest{
+			Body: string(result.ReqBody),
+		},
+		Response: htmlResponse{
+			StatusCode: result.Resp.http.StatusCode,
+			Headers:    map[string][]string(result.Resp.http.Header),
+			Body:       prettyPrint(result.Resp.http.Header.Get("Content-Type"), result.Resp.body),
+		},
+	}
+
+	if req := result.Resp.http.Request; req != nil {
+		c.Request.Method = req.Method
+		c.Request.URL = req.URL.String()
+		c.Request.Headers = flattenHeaders(req.Header)
+	}
+
+	if !passed {
+		c.FailedExpectation = result.Cause.Error()
+	}
+
+	s.Cases = append(s.Cases, c)
+}
+
+// Flush renders the index and per-case pages to OutDir.
+func (r *HTMLReporter) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(r.OutDir, 0755); err != nil {
+		debugMsg("HTMLReporter: can't create", r.OutDir, ":", err)
+		return
+	}
+
+	tmpl, err := r.templates()
+	if err != nil {
+		debugMsg("HTMLReporter: can't load templates:", err)
+		return
+	}
+
+	var suites []*htmlSuite
+	for _, name := range r.order {
+		suites = append(suites, r.suites[name])
+	}
+
+	for _, s := range suites {
+		for i := range s.Cases {
+			s.Cases[i].File = filepath.ToSlash(filepath.Join(s.Name, caseFileName(i, s.Cases[i].Name)))
+		}
+	}
+
+	index, err := os.Create(filepath.Join(r.OutDir, "index.html"))
+	if err != nil {
+		debugMsg("HTMLReporter: can't create index.html:", err)
+		return
+	}
+	defer index.Close()
+
+	if err := tmpl.ExecuteTemplate(index, "index.html.tmpl", suites); err != nil {
+		debugMsg("HTMLReporter: can't render index.html:", err)
+	}
+
+	for _, s := range suites {
+		suiteDir := filepath.Join(r.OutDir, s.Name)
+		if err := os.MkdirAll(suiteDir, 0755); err != nil {
+			continue
+		}
+
+		for i, c := range s.Cases {
+			path := filepath.Join(suiteDir, caseFileName(i, c.Name))
+			f, err := os.Create(path)
+			if err != nil {
+				continue
+			}
+
+			if err := tmpl.ExecuteTemplate(f, "case.html.tmpl", c); err != nil {
+				debugMsg("HTMLReporter: can't render", path, ":", err)
+			}
+			f.Close()
+		}
+	}
+}
+
+func (r *HTMLReporter) templates() (*template.Template, error) {
+	return template.ParseGlob(filepath.Join(r.TemplateDir, "*.html.tmpl"))
+}
+
+// flattenHeaders collapses a net/http header map (one or more values per
+// key) down to one string per key, which is all the report template
+// needs to display.
+func flattenHeaders(h http.Header) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		out[k] = strings.Join(v, ", ")
+	}
+	return out
+}
+
+// prettyPrint indents a JSON response body for readability in the
+// report; any other content-type, or invalid JSON, is returned as-is.
+func prettyPrint(contentType string, body []byte) string {
+	if !strings.Contains(contentType, "json") {
+		return string(body)
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, body, "", "  "); err != nil {
+		return string(body)
+	}
+
+	return buf.String()
+}
+
+func caseFileName(i int, name string) string {
+	safe := name
+	if safe == "" {
+		safe = "case"
+	}
+	return filepath.Clean(sanitizeFileName(safe)) + "-" + strconv.Itoa(i) + ".html"
+}
+
+func sanitizeFileName(s string) string {
+	out := make([]rune, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			out = append(out, r)
+		default:
+			out = append(out, '_')
+		}
+	}
+	return string(out)
+}
+
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+)
+
+// junitXMLSuite is the JUnit "testsuite" XML shape jenkins/most CI
+// dashboards expect.
+type junitXMLSuite struct {
+	XMLName  xml.Name       `xml:"testsuite"`
+	Name     string         `xml:"name,attr"`
+	Tests    int            `xml:"tests,attr"`
+	Failures int            `xml:"failures,attr"`
+	Cases    []junitXMLCase `xml:"testcase"`
+}
+
+type junitXMLCase struct {
+	Name    string           `xml:"name,attr"`
+	Time    float64          `xml:"time,attr"`
+	Failure *junitXMLFailure `xml:"failure,omitempty"`
+}
+
+type junitXMLFailure struct {
+	Message string `xml:"message,attr"`
+}
+
+// writeJUnitXML renders s as "<outDir>/<suite name>.xml", with every
+// character of the name other than a-z, A-Z, 0-9, '-' and '_' replaced by '_'.
+func writeJUnitXML(outDir string, s *junitSuite) {
+	xs := junitXMLSuite{
+		Name:     s.Name,
+		Tests:    s.Passed + s.Failed,
+		Failures: s.Failed,
+	}
+
+	for _, c := range s.Cases {
+		xc := junitXMLCase{Name: c.Name, Time: c.Duration}
+		if !c.Passed {
+			xc.Failure = &junitXMLFailure{Message: c.Failure}
+		}
+		xs.Cases = append(xs.Cases, xc)
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		debugMsg("JUnitReporter: can't create", outDir, ":", err)
+		return
+	}
+
+	path := filepath.Join(outDir, sanitizeFileName(s.Name)+".xml")
+	f, err := os.Create(path)
+	if err != nil {
+		debugMsg("JUnitReporter: can't create", path, ":", err)
+		return
+	}
+	defer f.Close()
+
+	enc := xml.NewEncoder(f)
+	enc.Indent("", "  ")
+	if err := enc.Encode(xs); err != nil {
+		debugMsg("JUnitReporter: can't encode", path, ":", err)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+// BackoffStrategy picks the delay before retry attempt n (1-based: the
+// delay before the 2nd attempt, before the 3rd, and so on).
+type BackoffStrategy string
+
+const (
+	// BackoffConstant waits the same delay before every retry.
+	BackoffConstant BackoffStrategy = "constant"
+	// BackoffExponential doubles the delay on every retry.
+	BackoffExponential BackoffStrategy = "exponential"
+	// BackoffJitter is exponential backoff with up to +/-50% random jitter,
+	// to avoid a thundering herd against a recovering server.
+	BackoffJitter BackoffStrategy = "jitter"
+)
+
+// RetryPolicy is the "retry" block on a Call. RetryOn conditions are
+// "network" (the request itself errored), "expect" (the response failed
+// one of the call's own expect checks), "5xx"/"4xx", or a literal status
+// code such as "429". An empty RetryOn retries only on network errors.
+type RetryPolicy struct {
+	MaxAttempts int             `json:"maxAttempts"`
+	Backoff     BackoffStrategy `json:"backoff"`
+	Delay       string          `json:"delay"`
+	RetryOn     []string        `json:"retryOn"`
+}
+
+// AttemptResult records the outcome of a single attempt of a call;
+// TestResult.Attempts holds one per attempt. The console, JUnit and HTML
+// reporters do not include the attempts in their output.
+type AttemptResult struct {
+	Number   int
+	Duration time.Duration
+	Status   int
+	Err      error
+}
+
+func (p RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay, err := time.ParseDuration(p.Delay)
+	if err != nil || delay == 0 {
+		delay = time.Second
+	}
+
+	switch p.Backoff {
+	case BackoffExponential, BackoffJitter:
+		delay = delay * (1 << uint(attempt-1))
+	}
+
+	if p.Backoff == BackoffJitter {
+		jitter := 0.5 + rand.Float64() // [0.5, 1.5)
+		delay = time.Duration(float64(delay) * jitter)
+	}
+
+	return delay
+}
+
+// shouldRetry reports whether attempt should be retried given the response
+// status (0 if the request errored), the request error (if any), and the
+// expectation error from checking the response against the call's expect
+// block (if any, and only evaluated when the request itself succeeded).
+func (p RetryPolicy) shouldRetry(statusCode int, err error, expectErr error) bool {
+	if len(p.RetryOn) == 0 {
+		return err != nil
+	}
+
+	for _, cond := range p.RetryOn {
+		switch cond {
+		case "network":
+			if err != nil {
+				return true
+			}
+		case "expect":
+			if expectErr != nil {
+				return true
+			}
+		default:
+			if statusCodeMatches(cond, statusCode) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func statusCodeMatches(cond string, statusCode int) bool {
+	switch cond {
+	case "5xx":
+		return statusCode >= 500 && statusCode < 600
+	case "4xx":
+		return statusCode >= 400 && statusCode < 500
+	default:
+		code, err := strconv.Atoi(cond)
+		return err == nil && code == statusCode
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyMaxAttempts(t *testing.T) {
+	cases := []struct {
+		name string
+		p    RetryPolicy
+		want int
+	}{
+		{"zero value defaults to 1", RetryPolicy{}, 1},
+		{"negative defaults to 1", RetryPolicy{MaxAttempts: -1}, 1},
+		{"explicit value is kept", RetryPolicy{MaxAttempts: 3}, 3},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.p.maxAttempts(); got != c.want {
+				t.Errorf("maxAttempts() = %d, want %d", got, c.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	cases := []struct {
+		name    string
+		p       RetryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{"invalid delay defaults to 1s", RetryPolicy{Delay: "not-a-duration"}, 1, time.Second},
+		{"constant ignores attempt number", RetryPolicy{Delay: "200ms", Backoff: BackoffConstant}, 3, 200 * time.Millisecond},
+		{"exponential doubles per attempt", RetryPolicy{Delay: "100ms", Backoff: BackoffExponential}, 3, 400 * time.Millisecond},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.p.backoff(c.attempt); got != c.want {
+				t.Errorf("backoff(%d) = %s, want %s", c.attempt, got, c.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoffJitter(t *testing.T) {
+	p := RetryPolicy{Delay: "100ms", Backoff: BackoffJitter}
+	base := 400 * time.Millisecond // 100ms * 2^(3-1)
+	lo := time.Duration(float64(base) * 0.5)
+	hi := time.Duration(float64(base) * 1.5)
+
+	for i := 0; i < 20; i++ {
+		got := p.backoff(3)
+		if got < lo || got > hi {
+			t.Fatalf("backoff(3) = %s, want within [%s, %s]", got, lo, hi)
+		}
+	}
+}
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	cases := []struct {
+		name       string
+		p          RetryPolicy
+		statusCode int
+		err        error
+		expectErr  error
+		want       bool
+	}{
+		{"no retryOn retries on error", RetryPolicy{}, 0, errors.New("boom"), nil, true},
+		{"no retryOn does not retry on success", RetryPolicy{}, 200, nil, nil, false},
+		{"no retryOn ignores expect failures", RetryPolicy{}, 200, nil, errors.New("bad body"), false},
+		{"network condition matches error", RetryPolicy{RetryOn: []string{"network"}}, 0, errors.New("boom"), nil, true},
+		{"network condition ignores status", RetryPolicy{RetryOn: []string{"network"}}, 503, nil, nil, false},
+		{"5xx condition matches", RetryPolicy{RetryOn: []string{"5xx"}}, 503, nil, nil, true},
+		{"5xx condition doesn't match 4xx", RetryPolicy{RetryOn: []string{"5xx"}}, 404, nil, nil, false},
+		{"4xx condition matches", RetryPolicy{RetryOn: []string{"4xx"}}, 429, nil, nil, true},
+		{"explicit status code matches", RetryPolicy{RetryOn: []string{"429"}}, 429, nil, nil, true},
+		{"explicit status code doesn't match", RetryPolicy{RetryOn: []string{"429"}}, 500, nil, nil, false},
+		{"expect condition matches an expectation failure", RetryPolicy{RetryOn: []string{"expect"}}, 200, nil, errors.New("bad body"), true},
+		{"expect condition ignores a passing expectation", RetryPolicy{RetryOn: []string{"expect"}}, 200, nil, nil, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.p.shouldRetry(c.statusCode, c.err, c.expectErr); got != c.want {
+				t.Errorf("shouldRetry(%d, %v, %v) = %v, want %v", c.statusCode, c.err, c.expectErr, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// StreamMode selects how a call's response body is read.
+type StreamMode string
+
+const (
+	// StreamNone reads the whole body at once, same as before streaming
+	// support existed. It's the default when Call.On.Stream is empty.
+	StreamNone StreamMode = ""
+	// StreamSSE reads the body as a Server-Sent Events stream.
+	StreamSSE StreamMode = "sse"
+	// StreamNDJSON reads the body as newline-delimited JSON.
+	StreamNDJSON StreamMode = "ndjson"
+	// StreamChunks reads the body as raw chunks, one per Read/flush.
+	StreamChunks StreamMode = "chunks"
+)
+
+// StreamEvent is one unit received off a streamed response, normalized
+// across SSE/ndjson/chunks so expect.events can match against it the same
+// way regardless of mode.
+type StreamEvent struct {
+	Event string
+	Data  string
+}
+
+// ResponseReader reads a response body, either all at once (the existing
+// behaviour) or incrementally as a stream of events. Factoring this out
+// of call() keeps the buffered path as the default while making
+// streaming an opt-in path that still feeds [remember] from the last
+// event it saw.
+type ResponseReader interface {
+	// Read consumes resp.Body and returns the bytes to treat as "the
+	// response body" for expectations/remember, along with every event
+	// observed (empty for the buffered reader).
+	Read(resp *http.Response) ([]byte, []StreamEvent, error)
+}
+
+// BufferedReader is the default, non-streaming ResponseReader: it reads
+// the entire body before returning.
+type BufferedReader struct{}
+
+func (BufferedReader) Read(resp *http.Response) ([]byte, []StreamEvent, error) {
+	body, err := ioutil.ReadAll(resp.Body)
+	return body, nil, err
+}
+
+// StreamReader reads a response incrementally in the given mode, stopping
+// at MaxDuration if it is set and non-zero.
+type StreamReader struct {
+	Mode        StreamMode
+	MaxDuration time.Duration
+}
+
+// Read enforces MaxDuration, if set, by closing resp.Body once the timer
+// fires. A stalled SSE/ndjson/chunk connection blocks inside a single
+// Scan()/Read() call with no natural point to check a deadline between
+// reads, so the only way to bound it is to make the blocking call itself
+// return - closing the body does that, the same way http.Client's own
+// Timeout unblocks a stuck read.
+func (r StreamReader) Read(resp *http.Response) ([]byte, []StreamEvent, error) {
+	if r.MaxDuration > 0 {
+		timer := time.AfterFunc(r.MaxDuration, func() { resp.Body.Close() })
+		defer timer.Stop()
+	}
+
+	var events []StreamEvent
+	switch r.Mode {
+	case StreamSSE:
+		events = readSSE(resp.Body)
+	case StreamNDJSON:
+		events = readNDJSON(resp.Body)
+	case StreamChunks:
+		events = readChunks(resp.Body)
+	default:
+		return nil, nil, fmt.Errorf("unknown stream mode: %q", r.Mode)
+	}
+
+	var last string
+	if len(events) > 0 {
+		last = events[len(events)-1].Data
+	}
+
+	return []byte(last), events, nil
+}
+
+func readSSE(body io.Reader) []StreamEvent {
+	var events []StreamEvent
+	scanner := bufio.NewScanner(body)
+
+	cur := StreamEvent{}
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if cur.Data != "" || cur.Event != "" {
+				events = append(events, cur)
+				cur = StreamEvent{}
+			}
+		case strings.HasPrefix(line, "event:"):
+			cur.Event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			if cur.Data != "" {
+				cur.Data += "\n" + data
+			} else {
+				cur.Data = data
+			}
+		}
+	}
+
+	if cur.Data != "" || cur.Event != "" {
+		events = append(events, cur)
+	}
+
+	return events
+}
+
+func readNDJSON(body io.Reader) []StreamEvent {
+	var events []StreamEvent
+	scanner := bufio.NewScanner(body)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var v interface{}
+		if err := json.Unmarshal([]byte(line), &v); err != nil {
+			continue
+		}
+
+		events = append(events, StreamEvent{Data: line})
+	}
+
+	return events
+}
+
+func readChunks(body io.Reader) []StreamEvent {
+	var events []StreamEvent
+	buf := make([]byte, 4096)
+
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			events = append(events, StreamEvent{Data: string(buf[:n])})
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	return events
+}
+
+// responseReaderFor picks the ResponseReader for a call, defaulting to
+// BufferedReader when on.stream isn't set.
+func responseReaderFor(on On) ResponseReader {
+	if on.Stream == StreamNone {
+		return BufferedReader{}
+	}
+
+	maxDuration, _ := time.ParseDuration(on.StreamMaxDuration)
+	return StreamReader{Mode: on.Stream, MaxDuration: maxDuration}
+}
+
+// EventExpectation is one entry of expect.events: it matches an event
+// name and/or runs path-expectations against the event's JSON data. Whether
+// expect.events is checked in order or as a set is the unrelated
+// expect.eventsAsSet flag (Expect.EventsAsSet), not a per-event property.
+type EventExpectation struct {
+	Event            string            `json:"event,omitempty"`
+	Data             string            `json:"data,omitempty"`
+	PathExpectations map[string]string `json:"path-expectations,omitempty"`
+}
+
+// checkEvents matches expected against the events actually observed on a
+// stream, in order unless asSet is true (checked per expectation: any
+// unmatched observed event satisfies it).
+func checkEvents(expected []EventExpectation, observed []StreamEvent, asSet bool) error {
+	if asSet {
+		return checkEventsAsSet(expected, observed)
+	}
+
+	if len(expected) > len(observed) {
+		return fmt.Errorf("expected %d stream events, got %d", len(expected), len(observed))
+	}
+
+	for i, exp := range expected {
+		if err := matchEvent(exp, observed[i]); err != nil {
+			return fmt.Errorf("event #%d: %s", i, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func checkEventsAsSet(expected []EventExpectation, observed []StreamEvent) error {
+	used := make([]bool, len(observed))
+
+	for _, exp := range expected {
+		found := false
+		for i, ev := range observed {
+			if used[i] {
+				continue
+			}
+			if matchEvent(exp, ev) == nil {
+				used[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("no observed event matched %+v", exp)
+		}
+	}
+
+	return nil
+}
+
+func matchEvent(exp EventExpectation, ev StreamEvent) error {
+	if exp.Event != "" && exp.Event != ev.Event {
+		return fmt.Errorf("expected event %q, got %q", exp.Event, ev.Event)
+	}
+
+	if exp.Data != "" && exp.Data != ev.Data {
+		return fmt.Errorf("expected data %q, got %q", exp.Data, ev.Data)
+	}
+
+	if len(exp.PathExpectations) > 0 {
+		if !json.Valid([]byte(ev.Data)) {
+			return fmt.Errorf("event data isn't valid JSON")
+		}
+
+		be := BodyExpectation{pathExpectations: exp.PathExpectations}
+		if err := be.check(Response{body: []byte(ev.Data)}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
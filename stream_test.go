@@ -0,0 +1,147 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadSSE(t *testing.T) {
+	input := "event: greeting\n" +
+		"data: hello\n" +
+		"\n" +
+		"data: line one\n" +
+		"data: line two\n" +
+		"\n"
+
+	events := readSSE(strings.NewReader(input))
+
+	want := []StreamEvent{
+		{Event: "greeting", Data: "hello"},
+		{Data: "line one\nline two"},
+	}
+
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event #%d = %+v, want %+v", i, events[i], want[i])
+		}
+	}
+}
+
+func TestReadSSETrailingEventWithoutBlankLine(t *testing.T) {
+	events := readSSE(strings.NewReader("event: done\ndata: ok\n"))
+
+	if len(events) != 1 || events[0] != (StreamEvent{Event: "done", Data: "ok"}) {
+		t.Fatalf("got %+v, want a single {done, ok} event", events)
+	}
+}
+
+func TestReadNDJSON(t *testing.T) {
+	input := "{\"a\":1}\n" +
+		"\n" +
+		"not json\n" +
+		"{\"a\":2}\n"
+
+	events := readNDJSON(strings.NewReader(input))
+
+	want := []StreamEvent{{Data: `{"a":1}`}, {Data: `{"a":2}`}}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event #%d = %+v, want %+v", i, events[i], want[i])
+		}
+	}
+}
+
+func TestReadChunks(t *testing.T) {
+	events := readChunks(strings.NewReader("abc"))
+
+	if len(events) != 1 || events[0].Data != "abc" {
+		t.Fatalf("got %+v, want a single {Data: \"abc\"} event", events)
+	}
+}
+
+// stalledReader never returns, simulating an idle SSE/chunked connection
+// that doesn't close the underlying body on its own.
+type stalledReader struct {
+	closed chan struct{}
+}
+
+func (r *stalledReader) Read(p []byte) (int, error) {
+	<-r.closed
+	return 0, io.EOF
+}
+
+func (r *stalledReader) Close() error {
+	close(r.closed)
+	return nil
+}
+
+func TestStreamReaderMaxDurationUnblocksStalledRead(t *testing.T) {
+	body := &stalledReader{closed: make(chan struct{})}
+	reader := StreamReader{Mode: StreamChunks, MaxDuration: 10 * time.Millisecond}
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := reader.Read(&http.Response{Body: body})
+		done <- err
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Read didn't return within 1s of a stalled body and a 10ms MaxDuration")
+	}
+}
+
+func TestCheckEventsInOrder(t *testing.T) {
+	observed := []StreamEvent{{Event: "a", Data: "1"}, {Event: "b", Data: "2"}}
+
+	if err := checkEvents([]EventExpectation{{Event: "a"}, {Event: "b"}}, observed, false); err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+
+	if err := checkEvents([]EventExpectation{{Event: "b"}, {Event: "a"}}, observed, false); err == nil {
+		t.Error("expected an order mismatch to fail")
+	}
+
+	if err := checkEvents([]EventExpectation{{Event: "a"}, {Event: "b"}, {Event: "c"}}, observed, false); err == nil {
+		t.Error("expected more expectations than observed events to fail")
+	}
+}
+
+func TestCheckEventsAsSet(t *testing.T) {
+	observed := []StreamEvent{{Event: "a", Data: "1"}, {Event: "b", Data: "2"}}
+
+	if err := checkEvents([]EventExpectation{{Event: "b"}, {Event: "a"}}, observed, true); err != nil {
+		t.Errorf("expected no error for an out-of-order set match, got %s", err)
+	}
+
+	if err := checkEvents([]EventExpectation{{Event: "a"}, {Event: "a"}}, observed, true); err == nil {
+		t.Error("expected matching the same observed event twice to fail")
+	}
+}
+
+func TestMatchEventPathExpectations(t *testing.T) {
+	ev := StreamEvent{Data: `{"status":"ok"}`}
+
+	err := matchEvent(EventExpectation{PathExpectations: map[string]string{"status": "ok"}}, ev)
+	if err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+
+	if err := matchEvent(EventExpectation{PathExpectations: map[string]string{"status": "fail"}}, ev); err == nil {
+		t.Error("expected a path-expectation mismatch to fail")
+	}
+
+	if err := matchEvent(EventExpectation{PathExpectations: map[string]string{"status": "ok"}}, StreamEvent{Data: "not json"}); err == nil {
+		t.Error("expected invalid JSON data to fail")
+	}
+}